@@ -0,0 +1,43 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package decryption backs the ArbDecryption precompile the same way
+// arbos/programs backs ArbWasm: the BLS12-381 public key ArbDecryption.Set
+// installs is kept in backingStorage so every node derives the same state
+// from the chain rather than from whatever was last held in memory.
+package decryption
+
+import (
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+type Decryption struct {
+	backingStorage *storage.Storage
+	publicKey      storage.StorageBackedBytes
+}
+
+var publicKeyKey = []byte{0}
+
+func Initialize(sto *storage.Storage) {
+	publicKey := sto.OpenStorageBackedBytes(publicKeyKey)
+	_ = publicKey.Set(nil)
+}
+
+func Open(sto *storage.Storage) *Decryption {
+	return &Decryption{
+		backingStorage: sto,
+		publicKey:      sto.OpenStorageBackedBytes(publicKeyKey),
+	}
+}
+
+// PublicKey returns the marshalled BLS12-381 G1 point installed by Set, or
+// nil if none has been installed yet.
+func (d *Decryption) PublicKey() ([]byte, error) {
+	return d.publicKey.Get()
+}
+
+// SetPublicKey persists the marshalled BLS12-381 G1 point. Callers are
+// expected to have already validated that it unmarshals correctly.
+func (d *Decryption) SetPublicKey(pk []byte) error {
+	return d.publicKey.Set(pk)
+}
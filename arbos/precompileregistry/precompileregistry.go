@@ -0,0 +1,60 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package precompileregistry tracks, per ArbOS version, which of the
+// entries in precompiles.Registry governance has enabled. It backs the
+// ArbPrecompileRegistry precompile, the same way arbos/programs backs
+// ArbWasm.
+package precompileregistry
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+// ActivationState tracks the entries governance has enabled so far.
+type ActivationState struct {
+	backingStorage *storage.Storage
+	activations    *storage.Storage
+}
+
+var activationsKey = []byte{0}
+
+func Initialize(sto *storage.Storage) {
+	// nothing to seed: every precompile starts disabled until Enable is called
+	_ = sto
+}
+
+func Open(sto *storage.Storage) *ActivationState {
+	return &ActivationState{
+		backingStorage: sto,
+		activations:    sto.OpenSubStorage(activationsKey),
+	}
+}
+
+// IsEnabled reports whether address is enabled as of arbosVersion. An entry
+// enabled at version V is active for all versions >= V until disabled again.
+func (a *ActivationState) IsEnabled(address common.Address, arbosVersion uint64) (bool, error) {
+	enabledAt, err := a.activations.GetUint64(address.Hash())
+	if err != nil {
+		return false, err
+	}
+	if enabledAt == 0 {
+		return false, nil
+	}
+	return arbosVersion >= enabledAt, nil
+}
+
+// Enable marks address as active starting at arbosVersion. This is the
+// activation journal: the version an entry turned on at is recorded in
+// backing storage, the same way StylusParams persists its config, and stays
+// there until Disable clears it.
+func (a *ActivationState) Enable(address common.Address, arbosVersion uint64) error {
+	return a.activations.SetUint64(address.Hash(), arbosVersion)
+}
+
+// Disable removes address from the active set. Re-enabling it later records
+// a fresh activation version rather than resurrecting the old one.
+func (a *ActivationState) Disable(address common.Address) error {
+	return a.activations.SetUint64(address.Hash(), 0)
+}
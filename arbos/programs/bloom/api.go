@@ -0,0 +1,42 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package bloom
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// API is registered under the "arb" RPC namespace as arb_findProgramBlocks.
+// It exposes Match as a request/response call rather than a channel, which
+// is what json-rpc wants; the node that owns the section store and the real
+// per-block blooms is responsible for constructing one of these and wiring
+// it into its RPC service list.
+type API struct {
+	store       SectionStore
+	blocks      BlockBloom
+	sectionSize uint64
+}
+
+func NewAPI(store SectionStore, blocks BlockBloom, sectionSize uint64) *API {
+	return &API{store: store, blocks: blocks, sectionSize: sectionSize}
+}
+
+// FindProgramBlocks returns candidate blocks in [from, to] that may have
+// activated or called every address in programs. Callers should treat the
+// result as already false-positive-checked against the real per-block bloom
+// (Match performs that verification), but, as with eth_getLogs, still expect
+// downstream log filtering to do the final, authoritative check.
+func (a *API) FindProgramBlocks(ctx context.Context, from, to uint64, programs []common.Address) ([]uint64, error) {
+	matches, err := Match(ctx, a.store, a.blocks, a.sectionSize, from, to, programs)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []uint64
+	for block := range matches {
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
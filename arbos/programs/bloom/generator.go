@@ -0,0 +1,121 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package bloom indexes Stylus program activity the way go-ethereum's
+// core/bloombits indexes transaction logs: blocks are grouped into
+// fixed-size sections, each section's per-block Bloom filters are rotated
+// into per-bit columns, and a range query then becomes a handful of
+// column ANDs instead of a linear scan over every block.
+//
+// Two bloom "kinds" are defined:
+//   - ProgramBloom: which program addresses were activated/called.
+//   - SlotBloom: which storage slots a program touched.
+//
+// Indexer writes and Match queries ProgramBloom sections only. SlotTouchBloom
+// builds a per-block SlotBloom filter, but nothing in this package writes or
+// matches SlotBloom sections.
+package bloom
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SectionSize is the number of blocks grouped into one section, matching
+// go-ethereum's default bloombits section size.
+const SectionSize uint64 = 4096
+
+// BloomBitLength is the number of bits in a types.Bloom, and so the number
+// of rotated-bitset columns a section generator produces.
+const BloomBitLength = types.BloomBitLength
+
+// Kind identifies which of a program's two blooms a query targets.
+type Kind uint8
+
+const (
+	ProgramBloom Kind = iota
+	SlotBloom
+)
+
+// Generator accumulates the per-block Bloom filters of a single section and
+// rotates them into BloomBitLength bitsets, one bit of the block's Bloom per
+// bitset, one bit of the bitset per block in the section. This is the same
+// transposition go-ethereum's bloombits.Generator performs; it is what makes
+// a later "does bit N ever get set across this section" query an O(1) fetch
+// of a single bitset rather than a scan of SectionSize blooms.
+type Generator struct {
+	sectionSize uint64
+	bits        [BloomBitLength][]byte // bitIndex -> bitset across the section
+	nextBlock   uint64                 // index of the next block expected, within the section
+}
+
+func NewGenerator(sectionSize uint64) (*Generator, error) {
+	if sectionSize%8 != 0 {
+		return nil, errors.New("bloom: section size must be a multiple of 8")
+	}
+	g := &Generator{sectionSize: sectionSize}
+	for i := range g.bits {
+		g.bits[i] = make([]byte, sectionSize/8)
+	}
+	return g, nil
+}
+
+// AddBloom folds the given block's Bloom filter into the section at index.
+// index must equal the number of blooms already added (blocks must be added
+// in order, exactly as go-ethereum's generator requires).
+func (g *Generator) AddBloom(index uint64, bloom types.Bloom) error {
+	if g.nextBlock != index {
+		return errors.New("bloom: blocks must be added in order")
+	}
+	byteIndex := index / 8
+	bitMask := byte(1) << byte(7-index%8)
+	for bit := 0; bit < BloomBitLength; bit++ {
+		if bloomBitSet(bloom, bit) {
+			g.bits[bit][byteIndex] |= bitMask
+		}
+	}
+	g.nextBlock++
+	return nil
+}
+
+// bloomBitSet reports whether the given bit (0 is the least significant bit
+// of the last byte, the numbering types.Bloom.Add uses) is set in bloom.
+func bloomBitSet(bloom types.Bloom, bit int) bool {
+	byteIndex := len(bloom) - 1 - bit/8
+	return bloom[byteIndex]&(1<<uint(bit%8)) != 0
+}
+
+// Bitset returns the rotated bitset for the given bloom bit index: one bit
+// per block in the section, set if that block's bloom had this bit set.
+func (g *Generator) Bitset(bit uint) ([]byte, error) {
+	if uint(bit) >= BloomBitLength {
+		return nil, errors.New("bloom: bit index out of range")
+	}
+	if g.nextBlock != g.sectionSize {
+		return nil, errors.New("bloom: section not yet complete")
+	}
+	return g.bits[bit], nil
+}
+
+// ProgramCallBloom builds the Bloom filter for a single block's worth of
+// program-activity: every address activated or called adds its own bits,
+// the same way go-ethereum's logs Bloom folds in each log's address.
+func ProgramCallBloom(programs []common.Address) types.Bloom {
+	var b types.Bloom
+	for _, program := range programs {
+		b.Add(program.Bytes())
+	}
+	return b
+}
+
+// SlotTouchBloom builds the Bloom filter for the storage slots a block's
+// Stylus calls read or wrote.
+func SlotTouchBloom(slots []common.Hash) types.Bloom {
+	var b types.Bloom
+	for _, slot := range slots {
+		b.Add(slot.Bytes())
+	}
+	return b
+}
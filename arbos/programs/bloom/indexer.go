@@ -0,0 +1,83 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package bloom
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Indexer implements programs.ActivityRecorder (by method shape, so the
+// programs package doesn't need to import bloom): it folds each CallProgram
+// into the current block's pending ProgramBloom, and once a block is
+// finalized, rotates that bloom into the open section's Generator, flushing
+// completed sections to store. This is the reference adapter that makes
+// arb_findProgramBlocks return real data instead of the RecordCall hook going
+// nowhere; a node with its own section storage can supply its own
+// ActivityRecorder instead.
+type Indexer struct {
+	store       SectionStore
+	sectionSize uint64
+
+	section   uint64
+	generator *Generator
+	pending   map[uint64][]common.Address // block -> programs called so far this block
+}
+
+func NewIndexer(store SectionStore, sectionSize uint64) (*Indexer, error) {
+	generator, err := NewGenerator(sectionSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Indexer{
+		store:       store,
+		sectionSize: sectionSize,
+		generator:   generator,
+		pending:     make(map[uint64][]common.Address),
+	}, nil
+}
+
+// RecordCall implements programs.ActivityRecorder.
+func (idx *Indexer) RecordCall(block uint64, program common.Address) {
+	idx.pending[block] = append(idx.pending[block], program)
+}
+
+// FinalizeBlock folds block's accumulated program-call bloom into the open
+// section, flushing the section to store once it fills. It's meant to be
+// called once per block from ArbOS block finalization, the same way
+// Programs.Reap is.
+func (idx *Indexer) FinalizeBlock(block uint64) error {
+	section := block / idx.sectionSize
+	if section != idx.section {
+		return fmt.Errorf("bloom: block %d is not in the open section %d", block, idx.section)
+	}
+	offset := block % idx.sectionSize
+	programs := idx.pending[block]
+	delete(idx.pending, block)
+
+	if err := idx.generator.AddBloom(offset, ProgramCallBloom(programs)); err != nil {
+		return err
+	}
+	if offset+1 != idx.sectionSize {
+		return nil
+	}
+
+	for bit := uint(0); bit < BloomBitLength; bit++ {
+		bitset, err := idx.generator.Bitset(bit)
+		if err != nil {
+			return err
+		}
+		if err := idx.store.WriteBitset(ProgramBloom, bit, section, bitset); err != nil {
+			return err
+		}
+	}
+	generator, err := NewGenerator(idx.sectionSize)
+	if err != nil {
+		return err
+	}
+	idx.generator = generator
+	idx.section++
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package bloom
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// BlockBloom is looked up by the matcher to run the false-positive
+// verification pass: a bitset match only says "this bit was set somewhere in
+// the section", the real per-block Bloom is what confirms the address or
+// slot was actually present in that specific block.
+type BlockBloom interface {
+	BlockBloom(block uint64, kind Kind) (interface{ Test(data []byte) bool }, error)
+}
+
+// Match streams block numbers in [from, to] whose ProgramBloom may contain
+// every address in programs, verified against the real per-block bloom to
+// rule out bitset false positives. It does not query SlotBloom. It mirrors
+// go-ethereum core/bloombits' Matcher.Matches, trimmed to a single
+// synchronous pass instead of a concurrent multi-stage pipeline -- adequate
+// for program lookups, which are far rarer than log filtering.
+func Match(ctx context.Context, store SectionStore, blocks BlockBloom, sectionSize uint64, from, to uint64, programs []common.Address) (chan uint64, error) {
+	out := make(chan uint64)
+	go func() {
+		defer close(out)
+		firstSection := from / sectionSize
+		lastSection := to / sectionSize
+		for section := firstSection; section <= lastSection; section++ {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			// Read every (bit, section) pair this query needs once, rather
+			// than once per block: all sectionSize blocks in the section
+			// test against these same bytes.
+			bitsets, err := sectionBitsets(store, section, programs)
+			if err != nil {
+				continue
+			}
+			sectionStart := section * sectionSize
+			blockStart, blockEnd := sectionStart, sectionStart+sectionSize-1
+			if from > blockStart {
+				blockStart = from
+			}
+			if to < blockEnd {
+				blockEnd = to
+			}
+			for block := blockStart; block <= blockEnd; block++ {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				offset := uint(block % sectionSize)
+				if !sectionMayContain(bitsets, offset, programs) {
+					continue
+				}
+				verified, err := verifyBlock(blocks, block, programs)
+				if err != nil || !verified {
+					continue
+				}
+				select {
+				case out <- block:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// sectionBitsets reads the bitset for every distinct bit the programs hash to
+// in section, once each, so the per-block loop in Match only ever tests
+// in-memory bytes instead of re-reading the section from disk per block.
+func sectionBitsets(store SectionStore, section uint64, programs []common.Address) (map[uint][]byte, error) {
+	bitsets := make(map[uint][]byte)
+	for _, program := range programs {
+		for _, bit := range bloomBits(program.Bytes()) {
+			if _, ok := bitsets[bit]; ok {
+				continue
+			}
+			bitset, err := store.ReadBitset(ProgramBloom, bit, section)
+			if err != nil {
+				return nil, err
+			}
+			bitsets[bit] = bitset
+		}
+	}
+	return bitsets, nil
+}
+
+// sectionMayContain tests offset against the section's cached bitsets, with
+// no disk access: it's a pure in-memory bit test on what sectionBitsets read.
+func sectionMayContain(bitsets map[uint][]byte, offset uint, programs []common.Address) bool {
+	for _, program := range programs {
+		for _, bit := range bloomBits(program.Bytes()) {
+			if !bitSet(bitsets[bit], offset) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func verifyBlock(blocks BlockBloom, block uint64, programs []common.Address) (bool, error) {
+	bloomFilter, err := blocks.BlockBloom(block, ProgramBloom)
+	if err != nil {
+		return false, err
+	}
+	for _, program := range programs {
+		if !bloomFilter.Test(program.Bytes()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func bitSet(bitset []byte, offset uint) bool {
+	byteIndex := offset / 8
+	if int(byteIndex) >= len(bitset) {
+		return false
+	}
+	mask := byte(1) << (7 - offset%8)
+	return bitset[byteIndex]&mask != 0
+}
+
+// bloomBits returns the 3 bloom-filter bit indices data hashes to, matching
+// the scheme types.Bloom.Add uses, so matcher queries stay consistent with
+// how blocks were indexed by Generator/ProgramCallBloom.
+func bloomBits(data []byte) [3]uint {
+	hash := crypto.Keccak256(data)
+	var bits [3]uint
+	for i := 0; i < 3; i++ {
+		bits[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) & 2047
+	}
+	return bits
+}
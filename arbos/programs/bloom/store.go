@@ -0,0 +1,68 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package bloom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SectionStore persists and retrieves the rotated bitsets produced by a
+// Generator. FreezerStore is the on-disk implementation; tests and callers
+// that don't want to touch disk can supply their own (e.g. an in-memory map).
+type SectionStore interface {
+	WriteBitset(kind Kind, bit uint, section uint64, bitset []byte) error
+	ReadBitset(kind Kind, bit uint, section uint64) ([]byte, error)
+}
+
+// FreezerStore lays sections out one file per (kind, bit) pair, each file a
+// flat append of fixed-size (SectionSize/8 byte) bitsets indexed by section
+// number -- the same append-only, fixed-record-size layout go-ethereum's
+// freezer tables use for immutable chain data.
+type FreezerStore struct {
+	dir         string
+	sectionSize uint64
+}
+
+func NewFreezerStore(dir string, sectionSize uint64) *FreezerStore {
+	return &FreezerStore{dir: dir, sectionSize: sectionSize}
+}
+
+func (f *FreezerStore) recordSize() int64 {
+	return int64(f.sectionSize / 8)
+}
+
+func (f *FreezerStore) tableFile(kind Kind, bit uint) string {
+	return filepath.Join(f.dir, fmt.Sprintf("programs.%d.%04d.bloom", kind, bit))
+}
+
+func (f *FreezerStore) WriteBitset(kind Kind, bit uint, section uint64, bitset []byte) error {
+	if int64(len(bitset)) != f.recordSize() {
+		return fmt.Errorf("bloom: bitset is %d bytes, want %d", len(bitset), f.recordSize())
+	}
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.tableFile(kind, bit), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteAt(bitset, int64(section)*f.recordSize())
+	return err
+}
+
+func (f *FreezerStore) ReadBitset(kind Kind, bit uint, section uint64) ([]byte, error) {
+	file, err := os.Open(f.tableFile(kind, bit))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	bitset := make([]byte, f.recordSize())
+	if _, err := file.ReadAt(bitset, int64(section)*f.recordSize()); err != nil {
+		return nil, err
+	}
+	return bitset, nil
+}
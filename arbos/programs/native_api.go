@@ -138,7 +138,13 @@ type apiClosure struct {
 	ecrecover       ecrecoverType
 }
 
+// newAPI wires the hostio closures the arbitrator calls back into. When
+// counter is non-nil, each closure is wrapped so calling it also increments
+// the matching hostio's count, feeding the StylusTrace the graphql
+// stylusCall field resolves to. Pass a nil counter to skip the bookkeeping,
+// e.g. for calls made outside of a traced transaction.
 func newAPI(
+	counter *HostioCounter,
 	addressBalance addressBalanceType,
 	addressCodeHash addressCodeHashType,
 	blockHash blockHashType,
@@ -153,21 +159,65 @@ func newAPI(
 	emitLog emitLogType,
 	ecrecover ecrecoverType,
 ) C.GoApi {
+	count := func(name string) {
+		if counter != nil {
+			counter.Increment(name)
+		}
+	}
 	id := atomic.AddInt64(&apiIds, 1)
 	apiClosures.Store(id, apiClosure{
-		addressBalance:  addressBalance,
-		addressCodeHash: addressCodeHash,
-		blockHash:       blockHash,
-		getBytes32:      getBytes32,
-		setBytes32:      setBytes32,
-		contractCall:    contractCall,
-		delegateCall:    delegateCall,
-		staticCall:      staticCall,
-		create1:         create1,
-		create2:         create2,
-		getReturnData:   getReturnData,
-		emitLog:         emitLog,
-		ecrecover:       ecrecover,
+		addressBalance: func(address common.Address) (*big.Int, uint64) {
+			count("address_balance")
+			return addressBalance(address)
+		},
+		addressCodeHash: func(address common.Address) (common.Hash, uint64) {
+			count("address_code_hash")
+			return addressCodeHash(address)
+		},
+		blockHash: func(block *big.Int) (common.Hash, uint64) {
+			count("block_hash")
+			return blockHash(block)
+		},
+		getBytes32: func(key common.Hash) (common.Hash, uint64) {
+			count("get_bytes32")
+			return getBytes32(key)
+		},
+		setBytes32: func(key, value common.Hash) (uint64, error) {
+			count("set_bytes32")
+			return setBytes32(key, value)
+		},
+		contractCall: func(contract common.Address, calldata []byte, gas uint64, value *big.Int) (uint32, uint64, error) {
+			count("contract_call")
+			return contractCall(contract, calldata, gas, value)
+		},
+		delegateCall: func(contract common.Address, calldata []byte, gas uint64) (uint32, uint64, error) {
+			count("delegate_call")
+			return delegateCall(contract, calldata, gas)
+		},
+		staticCall: func(contract common.Address, calldata []byte, gas uint64) (uint32, uint64, error) {
+			count("static_call")
+			return staticCall(contract, calldata, gas)
+		},
+		create1: func(code []byte, endowment *big.Int, gas uint64) (common.Address, uint32, uint64, error) {
+			count("create1")
+			return create1(code, endowment, gas)
+		},
+		create2: func(code []byte, salt, endowment *big.Int, gas uint64) (common.Address, uint32, uint64, error) {
+			count("create2")
+			return create2(code, salt, endowment, gas)
+		},
+		getReturnData: func() []byte {
+			count("get_return_data")
+			return getReturnData()
+		},
+		emitLog: func(data []byte, topics int) error {
+			count("emit_log")
+			return emitLog(data, topics)
+		},
+		ecrecover: func(data []byte) (common.Address, uint64) {
+			count("ecrecover")
+			return ecrecover(data)
+		},
 	})
 	return C.GoApi{
 		address_balance:   (*[0]byte)(C.addressBalanceWrap),
@@ -5,6 +5,7 @@ package programs
 
 import (
 	"errors"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -26,6 +27,10 @@ const initialKeepaliveDays = 31     // wait a month before allowing reactivation
 const initialInitTableBits = 7      // cache the last 128 programs
 const initialTrieTableBits = 11     // cache the hottest 1024 slots
 
+const initialReactivationTarget = 1024      // congestion curve targets this many active programs
+const initialReactivationMinFee = 0         // free to reactivate while under the target
+const initialReactivationMaxFee = 1_000_000 // evm gas, approached but never reached as load grows
+
 // This struct exists to collect the many Stylus configuration parameters into a single word.
 // The items here must only be modified in ArbOwner precompile methods (or in ArbOS upgrades).
 type StylusParams struct {
@@ -42,6 +47,14 @@ type StylusParams struct {
 	KeepaliveDays  uint16
 	InitTableBits  uint8
 	TrieTableBits  uint8
+
+	// Reactivation curve: priced like an EIP-1559 congestion fee over the
+	// count of currently-active programs rather than gas usage. These don't
+	// fit in the first storage word (already 31 of its 32 bytes are spoken
+	// for), so Params()/Save() read and write a second word for them.
+	ReactivationTarget uint32
+	ReactivationMinFee uint64
+	ReactivationMaxFee uint64
 }
 
 // Provides a view of the Stylus parameters. Call Save() to persist.
@@ -63,20 +76,39 @@ func (p Programs) Params() (*StylusParams, error) {
 		return value
 	}
 
+	// assume this second read is warm too -- it's the same frequency of
+	// access as the first, just split across two words once reactivation
+	// fields didn't fit in the first.
+	if err := sto.Burner().Burn(params.WarmStorageReadCostEIP2929); err != nil {
+		return &StylusParams{}, err
+	}
+
+	// paid for the read above
+	word2 := sto.GetFree(common.BigToHash(big.NewInt(1)))
+	data2 := word2[:]
+	take2 := func(count int) []byte {
+		value := data2[:count]
+		data2 = data2[count:]
+		return value
+	}
+
 	return &StylusParams{
-		backingStorage: sto,
-		Version:        am.BytesToUint16(take(2)),
-		InkPrice:       am.BytesToUint24(take(3)),
-		MaxStackDepth:  am.BytesToUint32(take(4)),
-		FreePages:      am.BytesToUint16(take(2)),
-		PageGas:        am.BytesToUint16(take(2)),
-		PageRamp:       am.BytesToUint(take(8)),
-		PageLimit:      am.BytesToUint16(take(2)),
-		MinInitGas:     am.BytesToUint16(take(2)),
-		ExpiryDays:     am.BytesToUint16(take(2)),
-		KeepaliveDays:  am.BytesToUint16(take(2)),
-		InitTableBits:  am.BytesToUint8(take(1)),
-		TrieTableBits:  am.BytesToUint8(take(1)),
+		backingStorage:     sto,
+		Version:            am.BytesToUint16(take(2)),
+		InkPrice:           am.BytesToUint24(take(3)),
+		MaxStackDepth:      am.BytesToUint32(take(4)),
+		FreePages:          am.BytesToUint16(take(2)),
+		PageGas:            am.BytesToUint16(take(2)),
+		PageRamp:           am.BytesToUint(take(8)),
+		PageLimit:          am.BytesToUint16(take(2)),
+		MinInitGas:         am.BytesToUint16(take(2)),
+		ExpiryDays:         am.BytesToUint16(take(2)),
+		KeepaliveDays:      am.BytesToUint16(take(2)),
+		InitTableBits:      am.BytesToUint8(take(1)),
+		TrieTableBits:      am.BytesToUint8(take(1)),
+		ReactivationTarget: am.BytesToUint32(take2(4)),
+		ReactivationMinFee: am.BytesToUint(take2(8)),
+		ReactivationMaxFee: am.BytesToUint(take2(8)),
 	}, nil
 }
 
@@ -103,7 +135,33 @@ func (p *StylusParams) Save() error {
 	)
 	word := common.Hash{}
 	copy(word[:], data) // right-pad with zeros
-	return p.backingStorage.SetByUint64(0, word)
+	if err := p.backingStorage.SetByUint64(0, word); err != nil {
+		return err
+	}
+
+	data2 := am.ConcatByteSlices(
+		am.Uint32ToBytes(p.ReactivationTarget),
+		am.UintToBytes(p.ReactivationMinFee),
+		am.UintToBytes(p.ReactivationMaxFee),
+	)
+	word2 := common.Hash{}
+	copy(word2[:], data2)
+	return p.backingStorage.SetByUint64(1, word2)
+}
+
+// ReactivationFee prices ArbWasm.Reactivate the way base fee prices
+// execution: flat at ReactivationMinFee while activeCount is at or below
+// ReactivationTarget, then rising -- without a float in sight, since this
+// runs in consensus code -- toward ReactivationMaxFee as congestion grows,
+// asymptotically approaching but never reaching it.
+func (p *StylusParams) ReactivationFee(activeCount uint64) uint64 {
+	target := uint64(p.ReactivationTarget)
+	if target == 0 || activeCount <= target {
+		return p.ReactivationMinFee
+	}
+	over := activeCount - target
+	span := p.ReactivationMaxFee - p.ReactivationMinFee
+	return p.ReactivationMinFee + span*over/(over+target)
 }
 
 func initStylusParams(sto *storage.Storage) {
@@ -121,6 +179,10 @@ func initStylusParams(sto *storage.Storage) {
 		KeepaliveDays:  initialKeepaliveDays,
 		InitTableBits:  initialInitTableBits,
 		TrieTableBits:  initialTrieTableBits,
+
+		ReactivationTarget: initialReactivationTarget,
+		ReactivationMinFee: initialReactivationMinFee,
+		ReactivationMaxFee: initialReactivationMaxFee,
 	}
 	_ = params.Save()
 }
@@ -17,24 +17,66 @@ import (
 	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
+// ActivityRecorder receives a bloom-indexable record of each CallProgram,
+// so the bloom package can fold it into the current section without this
+// package depending on how sections get persisted (freezer vs memory vs
+// whatever the node is backed by).
+type ActivityRecorder interface {
+	RecordCall(block uint64, program common.Address)
+}
+
+// TraceRecorder receives the StylusTrace CallProgram assembles for the
+// current transaction, so the graphql stylusCall resolver can look it up
+// later without this package depending on how traces get indexed per block.
+type TraceRecorder interface {
+	RecordTrace(trace *StylusTrace)
+}
+
 const MaxWasmSize = 64 * 1024
 
 type Programs struct {
 	backingStorage  *storage.Storage
 	machineVersions *storage.Storage
+	activatedAt     *storage.Storage // program hash -> block number last (re)activated
+	deactivated     *storage.Storage // program hash -> 1 if the reaper has expired it
+	programList     *storage.Storage // index -> program address, for the reaper to walk
 	wasmGasPrice    storage.StorageBackedUBips
 	wasmMaxDepth    storage.StorageBackedUint32
 	wasmHostioCost  storage.StorageBackedUint64
 	version         storage.StorageBackedUint32
+	programCount    storage.StorageBackedUint64
+	reaperCursor    storage.StorageBackedUint64
+	activeCount     storage.StorageBackedUint64 // live count of non-deactivated programs, kept in sync by CompileProgram/Reap/Reactivate
+	activity        ActivityRecorder            // nil unless SetActivityRecorder is called
+	trace           TraceRecorder               // nil unless SetTraceRecorder is called
+}
+
+// SetActivityRecorder installs the bloom-indexing hook CallProgram reports
+// into. Nodes that don't care about arb_findProgramBlocks can leave this
+// unset; CallProgram skips the hook entirely when it's nil.
+func (p *Programs) SetActivityRecorder(activity ActivityRecorder) {
+	p.activity = activity
+}
+
+// SetTraceRecorder installs the hostio-trace hook CallProgram reports into.
+// Nodes that don't serve the graphql stylusCall field can leave this unset.
+func (p *Programs) SetTraceRecorder(trace TraceRecorder) {
+	p.trace = trace
 }
 
 var machineVersionsKey = []byte{0}
+var activatedAtKey = []byte{1}
+var deactivatedKey = []byte{2}
+var programListKey = []byte{3}
 
 const (
 	versionOffset uint64 = iota
 	wasmGasPriceOffset
 	wasmMaxDepthOffset
 	wasmHostioCostOffset
+	programCountOffset
+	reaperCursorOffset
+	activeCountOffset
 )
 
 func Initialize(sto *storage.Storage) {
@@ -42,23 +84,48 @@ func Initialize(sto *storage.Storage) {
 	wasmMaxDepth := sto.OpenStorageBackedUint32(wasmMaxDepthOffset)
 	wasmHostioCost := sto.OpenStorageBackedUint32(wasmHostioCostOffset)
 	version := sto.OpenStorageBackedUint64(versionOffset)
+	programCount := sto.OpenStorageBackedUint64(programCountOffset)
+	reaperCursor := sto.OpenStorageBackedUint64(reaperCursorOffset)
+	activeCount := sto.OpenStorageBackedUint64(activeCountOffset)
 	_ = wasmGasPrice.Set(0)
 	_ = wasmMaxDepth.Set(math.MaxUint32)
 	_ = wasmHostioCost.Set(0)
 	_ = version.Set(1)
+	_ = programCount.Set(0)
+	_ = reaperCursor.Set(0)
+	_ = activeCount.Set(0)
 }
 
 func Open(sto *storage.Storage) *Programs {
 	return &Programs{
 		backingStorage:  sto,
 		machineVersions: sto.OpenSubStorage(machineVersionsKey),
+		activatedAt:     sto.OpenSubStorage(activatedAtKey),
+		deactivated:     sto.OpenSubStorage(deactivatedKey),
+		programList:     sto.OpenSubStorage(programListKey),
 		wasmGasPrice:    sto.OpenStorageBackedUBips(wasmGasPriceOffset),
 		wasmMaxDepth:    sto.OpenStorageBackedUint32(wasmMaxDepthOffset),
 		wasmHostioCost:  sto.OpenStorageBackedUint64(wasmHostioCostOffset),
 		version:         sto.OpenStorageBackedUint32(versionOffset),
+		programCount:    sto.OpenStorageBackedUint64(programCountOffset),
+		reaperCursor:    sto.OpenStorageBackedUint64(reaperCursorOffset),
+		activeCount:     sto.OpenStorageBackedUint64(activeCountOffset),
 	}
 }
 
+// ActivatedVersion returns the Stylus version a program was last compiled
+// against, or 0 if it has never been compiled.
+func (p Programs) ActivatedVersion(program common.Address) (uint32, error) {
+	return p.machineVersions.GetUint32(program.Hash())
+}
+
+// ActivatedAt returns the block number a program was last (re)activated at
+// via CompileProgram or Reactivate, used by the graphql resolvers and the reaper to
+// compute expiry.
+func (p Programs) ActivatedAt(program common.Address) (uint64, error) {
+	return p.activatedAt.GetUint64(program.Hash())
+}
+
 func (p Programs) StylusVersion() (uint32, error) {
 	return p.version.Get()
 }
@@ -87,7 +154,7 @@ func (p Programs) SetWasmHostioCost(cost uint64) error {
 	return p.wasmHostioCost.Set(cost)
 }
 
-func (p Programs) CompileProgram(statedb vm.StateDB, program common.Address) (uint32, error) {
+func (p Programs) CompileProgram(statedb vm.StateDB, program common.Address, blockNumber uint64) (uint32, error) {
 	version, err := p.StylusVersion()
 	if err != nil {
 		return 0, err
@@ -107,7 +174,36 @@ func (p Programs) CompileProgram(statedb vm.StateDB, program common.Address) (ui
 	if err := compileUserWasm(statedb, program, wasm, version); err != nil {
 		return 0, err
 	}
-	return version, p.machineVersions.SetUint32(program.Hash(), version)
+	if err := p.machineVersions.SetUint32(program.Hash(), version); err != nil {
+		return 0, err
+	}
+
+	priorActivation, err := p.activatedAt.GetUint64(program.Hash())
+	if err != nil {
+		return 0, err
+	}
+	wasDeactivated, err := p.IsDeactivated(program)
+	if err != nil {
+		return 0, err
+	}
+	if priorActivation == 0 {
+		if err := p.appendToProgramList(program); err != nil {
+			return 0, err
+		}
+	}
+	if priorActivation == 0 || wasDeactivated {
+		if err := p.adjustActiveCount(1); err != nil {
+			return 0, err
+		}
+	}
+	if err := p.deactivated.SetUint64(program.Hash(), 0); err != nil {
+		return 0, err
+	}
+	if err := p.activatedAt.SetUint64(program.Hash(), blockNumber); err != nil {
+		return 0, err
+	}
+	emitArbWasmEvent(statedb, arbWasmActivatedTopic, program, blockNumber)
+	return version, nil
 }
 
 func (p Programs) CallProgram(
@@ -118,6 +214,7 @@ func (p Programs) CallProgram(
 	readOnly bool,
 	txContext vm.TxContext,
 	blockContext vm.BlockContext,
+	txHash common.Hash,
 ) ([]byte, error) {
 	stylusVersion, err := p.StylusVersion()
 	if err != nil {
@@ -133,12 +230,45 @@ func (p Programs) CallProgram(
 	if programVersion != stylusVersion {
 		return nil, errors.New("program out of date, please recompile")
 	}
+	deactivated, err := p.IsDeactivated(program)
+	if err != nil {
+		return nil, err
+	}
+	if deactivated {
+		return nil, ErrProgramDeactivated
+	}
 	params, err := p.goParams(programVersion)
 	if err != nil {
 		return nil, err
 	}
 	evmContext := p.goEvmContext(readOnly, txContext, blockContext)
-	return callUserWasm(statedb, program, calldata, gas, params, evmContext)
+	if p.activity != nil && blockContext.BlockNumber != nil {
+		p.activity.RecordCall(blockContext.BlockNumber.Uint64(), program)
+	}
+
+	gasBefore := *gas
+	var counter *HostioCounter
+	if p.trace != nil {
+		counter = NewHostioCounter()
+	}
+	inkUsed, output, err := callUserWasm(statedb, program, calldata, gas, params, evmContext, counter)
+	if p.trace != nil {
+		var blockNumber uint64
+		if blockContext.BlockNumber != nil {
+			blockNumber = blockContext.BlockNumber.Uint64()
+		}
+		p.trace.RecordTrace(&StylusTrace{
+			TxHash:       txHash,
+			Program:      program,
+			BlockNumber:  blockNumber,
+			InkUsed:      uint64(inkUsed),
+			GasUsed:      gasBefore - *gas,
+			HostioCounts: counter.Counts(),
+			OutOfInk:     errors.Is(err, vm.ErrOutOfGas),
+			OutOfStack:   errors.Is(err, vm.ErrDepth),
+		})
+	}
+	return output, err
 }
 
 func getWasm(statedb vm.StateDB, program common.Address) ([]byte, error) {
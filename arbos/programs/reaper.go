@@ -0,0 +1,199 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package programs
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrProgramDeactivated is what CallProgram returns for a program the
+// reaper has expired. The only way back is ArbWasm.Reactivate.
+var ErrProgramDeactivated = errors.New("program deactivated: call ArbWasm.Reactivate")
+
+// reaperBatchSize bounds how many programs Reap walks per block, spreading
+// the expiry sweep out so a block's worth of work stays O(batch) rather
+// than O(every program ever activated).
+const reaperBatchSize = 64
+
+// BlocksPerDay approximates ExpiryDays/KeepaliveDays (calendar days) in block
+// count. Exported so the graphql resolvers compute the same expiryBlock/
+// keepaliveUntil the reaper actually expires programs at, instead of keeping
+// their own copy of this constant in lock-step by hand.
+// TODO: source this from the real block time oracle.
+const BlocksPerDay = 24 * 60 * 60 * 4
+
+var (
+	arbWasmActivatedTopic   = crypto.Keccak256Hash([]byte("ProgramActivated(address,uint256)"))
+	arbWasmDeactivatedTopic = crypto.Keccak256Hash([]byte("ProgramDeactivated(address,uint256)"))
+	arbWasmReactivatedTopic = crypto.Keccak256Hash([]byte("ProgramReactivated(address,uint256)"))
+	arbWasmAddress          = common.HexToAddress("0x71")
+)
+
+// IsDeactivated reports whether the reaper has expired program. It returns
+// false for a program that was never activated.
+func (p Programs) IsDeactivated(program common.Address) (bool, error) {
+	flag, err := p.deactivated.GetUint64(program.Hash())
+	return flag != 0, err
+}
+
+// ActiveProgramCount returns how many known programs are not currently
+// deactivated, the input to StylusParams.ReactivationFee's congestion curve.
+// It's a live counter kept in sync by adjustActiveCount rather than a scan
+// over every program ever activated, so pricing Reactivate stays O(1)
+// regardless of how many programs the chain has ever seen.
+func (p Programs) ActiveProgramCount() (uint64, error) {
+	return p.activeCount.Get()
+}
+
+// adjustActiveCount keeps the live activeCount counter in step with
+// CompileProgram, Reap, and Reactivate as programs move in and out of the
+// deactivated state.
+func (p Programs) adjustActiveCount(delta int64) error {
+	count, err := p.activeCount.Get()
+	if err != nil {
+		return err
+	}
+	if delta < 0 {
+		count -= uint64(-delta)
+	} else {
+		count += uint64(delta)
+	}
+	return p.activeCount.Set(count)
+}
+
+func (p Programs) appendToProgramList(program common.Address) error {
+	index, err := p.programCount.Get()
+	if err != nil {
+		return err
+	}
+	if err := p.programList.SetByUint64(index, program.Hash()); err != nil {
+		return err
+	}
+	return p.programCount.Set(index + 1)
+}
+
+func (p Programs) programAt(index uint64) (common.Address, bool, error) {
+	hash, err := p.programList.GetByUint64(index)
+	if err != nil {
+		return common.Address{}, false, err
+	}
+	if hash == (common.Hash{}) {
+		return common.Address{}, false, nil
+	}
+	return common.BytesToAddress(hash.Bytes()), true, nil
+}
+
+// Reap walks a bounded batch of known programs starting from the stored
+// cursor, deactivating any whose ExpiryDays has elapsed since they were
+// last (re)activated. It's meant to be called once per block, from ArbOS
+// block finalization, so the cost of sweeping every known program is spread
+// across many blocks instead of paid all at once.
+func (p Programs) Reap(statedb vm.StateDB, currentBlock uint64) error {
+	count, err := p.programCount.Get()
+	if err != nil || count == 0 {
+		return err
+	}
+	stylusParams, err := p.Params()
+	if err != nil {
+		return err
+	}
+	expiry := uint64(stylusParams.ExpiryDays) * BlocksPerDay
+
+	cursor, err := p.reaperCursor.Get()
+	if err != nil {
+		return err
+	}
+	walk := reaperBatchSize
+	if uint64(walk) > count {
+		walk = int(count)
+	}
+	for i := 0; i < walk; i++ {
+		index := (cursor + uint64(i)) % count
+		program, ok, err := p.programAt(index)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		deactivated, err := p.IsDeactivated(program)
+		if err != nil {
+			return err
+		}
+		if deactivated {
+			continue
+		}
+		activatedAt, err := p.activatedAt.GetUint64(program.Hash())
+		if err != nil {
+			return err
+		}
+		if activatedAt == 0 || currentBlock < activatedAt+expiry {
+			continue
+		}
+		if err := p.deactivated.SetUint64(program.Hash(), 1); err != nil {
+			return err
+		}
+		if err := p.adjustActiveCount(-1); err != nil {
+			return err
+		}
+		emitArbWasmEvent(statedb, arbWasmDeactivatedTopic, program, currentBlock)
+	}
+	return p.reaperCursor.Set((cursor + uint64(walk)) % count)
+}
+
+// ReactivationFee quotes the congestion fee Reactivate will charge to bring
+// program back. It does not mutate state, so a caller (ArbWasm.Reactivate)
+// can burn the gas before committing the reactivation.
+func (p Programs) ReactivationFee(program common.Address) (uint64, error) {
+	deactivated, err := p.IsDeactivated(program)
+	if err != nil {
+		return 0, err
+	}
+	if !deactivated {
+		return 0, errors.New("program is not deactivated")
+	}
+	stylusParams, err := p.Params()
+	if err != nil {
+		return 0, err
+	}
+	activeCount, err := p.ActiveProgramCount()
+	if err != nil {
+		return 0, err
+	}
+	return stylusParams.ReactivationFee(activeCount), nil
+}
+
+// Reactivate clears the deactivated flag for program. Callers are expected
+// to have already priced and burned ReactivationFee(program) first.
+func (p Programs) Reactivate(statedb vm.StateDB, program common.Address, currentBlock uint64) error {
+	if err := p.deactivated.SetUint64(program.Hash(), 0); err != nil {
+		return err
+	}
+	if err := p.activatedAt.SetUint64(program.Hash(), currentBlock); err != nil {
+		return err
+	}
+	if err := p.adjustActiveCount(1); err != nil {
+		return err
+	}
+	emitArbWasmEvent(statedb, arbWasmReactivatedTopic, program, currentBlock)
+	return nil
+}
+
+// emitArbWasmEvent logs a single-topic-plus-address event from ArbWasm, so
+// indexers watching ProgramActivated/ProgramDeactivated/ProgramReactivated
+// can observe state changes without polling StylusProgram.
+func emitArbWasmEvent(statedb vm.StateDB, topic common.Hash, program common.Address, blockNumber uint64) {
+	statedb.AddLog(&types.Log{
+		Address:     arbWasmAddress,
+		Topics:      []common.Hash{topic, program.Hash()},
+		Data:        common.BigToHash(new(big.Int).SetUint64(blockNumber)).Bytes(),
+		BlockNumber: blockNumber,
+	})
+}
@@ -20,6 +20,7 @@ func compileUserWasm(db vm.StateDB, arbDb ethdb.Database, program common.Address
 
 func callUserWasm(
 	db vm.StateDB, arbDb ethdb.Database, program common.Address, calldata []byte, gas *uint64, params *goParams,
+	counter *HostioCounter,
 ) (uint32, []byte, error) {
 	return 0, nil, errors.New("unimplemented")
 }
@@ -0,0 +1,159 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/programs"
+)
+
+// Backend is what the Stylus resolvers need from the node: read-only access
+// to Programs.Params()/machineVersions, the code stored at a program's
+// address, and the per-tx StylusTrace CallProgram assembled. Nodes wire up
+// a concrete Backend when they register this schema.
+type Backend interface {
+	StylusParams() (*programs.StylusParams, error)
+	ActivatedVersion(address common.Address) (uint32, error)
+	ActivatedAt(address common.Address) (uint64, error)
+	CodeHash(address common.Address) (common.Hash, error)
+	DecompressedSize(address common.Address) (int, error)
+	StylusTrace(txHash common.Hash) (*programs.StylusTrace, bool, error)
+}
+
+// Resolver implements the stylus* fields added to Query by stylusSchema.
+type Resolver struct {
+	backend Backend
+}
+
+func NewResolver(backend Backend) *Resolver {
+	return &Resolver{backend: backend}
+}
+
+type StylusProgramArgs struct {
+	Address Address
+}
+
+func (r *Resolver) StylusProgram(ctx context.Context, args StylusProgramArgs) (*StylusProgramResolver, error) {
+	address := common.Address(args.Address)
+	version, err := r.backend.ActivatedVersion(address)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+	activatedAt, err := r.backend.ActivatedAt(address)
+	if err != nil {
+		return nil, err
+	}
+	params, err := r.backend.StylusParams()
+	if err != nil {
+		return nil, err
+	}
+	codeHash, err := r.backend.CodeHash(address)
+	if err != nil {
+		return nil, err
+	}
+	decompressedSize, err := r.backend.DecompressedSize(address)
+	if err != nil {
+		return nil, err
+	}
+	expiryBlock := activatedAt + uint64(params.ExpiryDays)*programs.BlocksPerDay
+	return &StylusProgramResolver{
+		address:          address,
+		version:          version,
+		activatedAt:      activatedAt,
+		expiryBlock:      expiryBlock,
+		keepaliveUntil:   expiryBlock + uint64(params.KeepaliveDays)*programs.BlocksPerDay,
+		inkPrice:         uint64(params.InkPrice),
+		pageLimit:        uint64(params.PageLimit),
+		codeHash:         codeHash,
+		decompressedSize: uint64(decompressedSize),
+	}, nil
+}
+
+func (r *Resolver) StylusParams(ctx context.Context) (*StylusParamsResolver, error) {
+	params, err := r.backend.StylusParams()
+	if err != nil {
+		return nil, err
+	}
+	return &StylusParamsResolver{params: params}, nil
+}
+
+type StylusCallArgs struct {
+	TxHash Bytes32
+}
+
+func (r *Resolver) StylusCall(ctx context.Context, args StylusCallArgs) (*StylusCallResolver, error) {
+	trace, ok, err := r.backend.StylusTrace(common.Hash(args.TxHash))
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &StylusCallResolver{trace: trace}, nil
+}
+
+type StylusProgramResolver struct {
+	address          common.Address
+	version          uint32
+	activatedAt      uint64
+	expiryBlock      uint64
+	keepaliveUntil   uint64
+	inkPrice         uint64
+	pageLimit        uint64
+	codeHash         common.Hash
+	decompressedSize uint64
+}
+
+func (s *StylusProgramResolver) Address() Address       { return Address(s.address) }
+func (s *StylusProgramResolver) Version() Long          { return Long(s.version) }
+func (s *StylusProgramResolver) ActivatedAt() Long      { return Long(s.activatedAt) }
+func (s *StylusProgramResolver) ExpiryBlock() Long      { return Long(s.expiryBlock) }
+func (s *StylusProgramResolver) KeepaliveUntil() Long   { return Long(s.keepaliveUntil) }
+func (s *StylusProgramResolver) InkPrice() Long         { return Long(s.inkPrice) }
+func (s *StylusProgramResolver) PageLimit() Long        { return Long(s.pageLimit) }
+func (s *StylusProgramResolver) CodeHash() Bytes32      { return Bytes32(s.codeHash) }
+func (s *StylusProgramResolver) DecompressedSize() Long { return Long(s.decompressedSize) }
+
+type StylusParamsResolver struct {
+	params *programs.StylusParams
+}
+
+func (s *StylusParamsResolver) Version() Long       { return Long(s.params.Version) }
+func (s *StylusParamsResolver) InkPrice() Long      { return Long(s.params.InkPrice) }
+func (s *StylusParamsResolver) MaxStackDepth() Long { return Long(s.params.MaxStackDepth) }
+func (s *StylusParamsResolver) FreePages() Long     { return Long(s.params.FreePages) }
+func (s *StylusParamsResolver) PageGas() Long       { return Long(s.params.PageGas) }
+func (s *StylusParamsResolver) PageRamp() Long      { return Long(s.params.PageRamp) }
+func (s *StylusParamsResolver) PageLimit() Long     { return Long(s.params.PageLimit) }
+func (s *StylusParamsResolver) MinInitGas() Long    { return Long(s.params.MinInitGas) }
+func (s *StylusParamsResolver) ExpiryDays() Long    { return Long(s.params.ExpiryDays) }
+func (s *StylusParamsResolver) KeepaliveDays() Long { return Long(s.params.KeepaliveDays) }
+
+type StylusCallResolver struct {
+	trace *programs.StylusTrace
+}
+
+func (s *StylusCallResolver) Program() Address { return Address(s.trace.Program) }
+func (s *StylusCallResolver) InkUsed() Long    { return Long(s.trace.InkUsed) }
+func (s *StylusCallResolver) GasUsed() Long    { return Long(s.trace.GasUsed) }
+func (s *StylusCallResolver) OutOfInk() bool   { return s.trace.OutOfInk }
+func (s *StylusCallResolver) OutOfStack() bool { return s.trace.OutOfStack }
+
+func (s *StylusCallResolver) HostioCounts() []*HostioCountResolver {
+	counts := make([]*HostioCountResolver, 0, len(s.trace.HostioCounts))
+	for name, count := range s.trace.HostioCounts {
+		counts = append(counts, &HostioCountResolver{name: name, count: count})
+	}
+	return counts
+}
+
+type HostioCountResolver struct {
+	name  string
+	count uint64
+}
+
+func (h *HostioCountResolver) Name() string { return h.name }
+func (h *HostioCountResolver) Count() Long  { return Long(h.count) }
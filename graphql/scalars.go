@@ -0,0 +1,73 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Long, Bytes32 and Address mirror the scalar wrapper types go-ethereum's
+// own graphql package defines for the same reason: graphql-go needs a
+// concrete Go type per GraphQL scalar. Bytes32 and Address use the same
+// 0x-prefixed hex go-ethereum already uses everywhere else; Long is a plain
+// int64.
+
+type Long int64
+
+func (l Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*l = Long(v)
+	case int64:
+		*l = Long(v)
+	case float64:
+		*l = Long(v)
+	default:
+		return fmt.Errorf("unexpected type for Long: %v", v)
+	}
+	return nil
+}
+
+type Bytes32 common.Hash
+
+func (b Bytes32) ImplementsGraphQLType(name string) bool { return name == "Bytes32" }
+
+func (b Bytes32) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + common.Hash(b).Hex() + `"`), nil
+}
+
+func (b *Bytes32) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type for Bytes32: %v", input)
+	}
+	hash, err := hexutil.Decode(s)
+	if err != nil {
+		return err
+	}
+	copy(b[:], hash)
+	return nil
+}
+
+type Address common.Address
+
+func (a Address) ImplementsGraphQLType(name string) bool { return name == "Address" }
+
+func (a Address) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + common.Address(a).Hex() + `"`), nil
+}
+
+func (a *Address) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type for Address: %v", input)
+	}
+	*a = Address(common.HexToAddress(s))
+	return nil
+}
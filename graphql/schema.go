@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package graphql
+
+// stylusSchema extends the base go-ethereum graphql schema (txs, logs,
+// receipts) with the Stylus-specific fields: which programs are active,
+// the chain-wide Stylus config, and per-call traces. It's merged into the
+// node's schema the same way go-ethereum's own graphql package registers
+// its schema.
+const stylusSchema = `
+  # StylusProgram is a program that has been compiled (and, possibly since
+  # expired or been reactivated) by ArbWasm.CompileProgram.
+  type StylusProgram {
+    address: Address!
+    version: Long!
+    activatedAt: Long!
+    expiryBlock: Long!
+    keepaliveUntil: Long!
+    inkPrice: Long!
+    pageLimit: Long!
+    codeHash: Bytes32!
+    decompressedSize: Long!
+  }
+
+  # StylusParams decodes the StylusParams config from version through
+  # keepaliveDays; initTableBits, trieTableBits and the reactivation-curve
+  # fields are not exposed.
+  type StylusParams {
+    version: Long!
+    inkPrice: Long!
+    maxStackDepth: Long!
+    freePages: Long!
+    pageGas: Long!
+    pageRamp: Long!
+    pageLimit: Long!
+    minInitGas: Long!
+    expiryDays: Long!
+    keepaliveDays: Long!
+  }
+
+  # HostioCount is one entry of a StylusCall's hostio tally.
+  type HostioCount {
+    name: String!
+    count: Long!
+  }
+
+  # StylusCall is the trace of a single Stylus invocation within a tx.
+  type StylusCall {
+    program: Address!
+    inkUsed: Long!
+    gasUsed: Long!
+    hostioCounts: [HostioCount!]!
+    outOfInk: Boolean!
+    outOfStack: Boolean!
+  }
+
+  extend type Query {
+    stylusProgram(address: Address!): StylusProgram
+    stylusParams: StylusParams!
+    stylusCall(txHash: Bytes32!): StylusCall
+  }
+`
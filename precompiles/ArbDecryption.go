@@ -2,116 +2,211 @@ package precompiles
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"log"
 
 	enc "github.com/FairBlock/DistributedIBE/encryption"
 	"github.com/drand/kyber"
 	bls "github.com/drand/kyber-bls12381"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 type ArbDecryption struct {
 	Address addr // 0x23
-	pk      []byte
+}
+
+// Gas costs for the two BLS12-381 pairings Decrypt/BatchDecrypt perform,
+// shaped like the EIP-2537 BLS12_PAIRING precompile schedule: a fixed cost
+// per pairing plus a per-byte cost for the ciphertext being decrypted.
+const (
+	PairingGasCost        uint64 = 45000
+	CiphertextByteGasCost uint64 = 16
+)
+
+// minCiphertextLen is a conservative floor below which a DistributedIBE
+// ciphertext cannot possibly be well-formed (it is at least a compressed G2
+// point plus a symmetric payload header).
+const minCiphertextLen = 98
+
+// ValidateCiphertext rejects malformed ciphertexts before any gas is burned
+// or pairing is attempted.
+func ValidateCiphertext(cipherBytes []byte) error {
+	if len(cipherBytes) < minCiphertextLen {
+		return fmt.Errorf("ciphertext too short: got %v bytes, want at least %v", len(cipherBytes), minCiphertextLen)
+	}
+	return nil
+}
+
+// decryptGas is the EVM gas cost of decrypting a single ciphertext: one
+// pairing to verify the key, one to recover the shared secret, scaled by the
+// length of the ciphertext being processed.
+func decryptGas(cipherLen int) uint64 {
+	return 2*PairingGasCost + CiphertextByteGasCost*uint64(cipherLen)
 }
 
 func (con *ArbDecryption) Get(c ctx, evm mech) ([]byte, error) {
-	return con.pk, nil
+	return c.State.Decryption().PublicKey()
 }
 
 func (con *ArbDecryption) Set(c ctx, evm mech, _pk []byte) (bool, error) {
 	suite := bls.NewBLS12381Suite()
 	pkPoint := suite.G1().Point()
 
-	// Log input public key bytes
-	log.Printf("Set: Public key bytes received: %x\n", _pk)
-
-	// Unmarshal the public key
-	err := pkPoint.UnmarshalBinary(_pk)
-	if err != nil {
-		log.Printf("Set: Error unmarshalling public key: %v\n", err)
+	if err := pkPoint.UnmarshalBinary(_pk); err != nil {
+		log.Debug("ArbDecryption Set: failed to unmarshal public key", "err", err)
 		return false, err
 	}
 
-	// Store the public key
-	con.pk = _pk
+	if err := c.State.Decryption().SetPublicKey(_pk); err != nil {
+		return false, err
+	}
 
-	log.Println("Set: Public key successfully set.")
+	log.Debug("ArbDecryption Set: public key installed")
 	return true, nil
 }
 
 func (con *ArbDecryption) Decrypt(c ctx, evm mech, privateKeyByte []byte, cipherBytes []byte, id string) ([]byte, error) {
-	suite := bls.NewBLS12381Suite()
-	privateKeyPoint := suite.G2().Point()
-
-	// Log input private key bytes and cipher bytes
-	log.Printf("Decrypt: Private key bytes received: %x\n", privateKeyByte)
-	log.Printf("Decrypt: Cipher bytes received: %x\n", cipherBytes)
+	if err := ValidateCiphertext(cipherBytes); err != nil {
+		return nil, err
+	}
+	if err := c.Burner().Burn(decryptGas(len(cipherBytes))); err != nil {
+		return nil, err
+	}
 
-	// Unmarshal the private key
-	err := privateKeyPoint.UnmarshalBinary(privateKeyByte)
+	pk, err := c.State.Decryption().PublicKey()
 	if err != nil {
-		log.Printf("Decrypt: Error unmarshalling private key: %v\n", err)
-		return []byte{1}, err
+		return nil, err
 	}
-	log.Printf("Decrypt: Public key: %v\n", con.pk)
-	// Unmarshal the stored public key
-	pkPoint := suite.G1().Point()
-	err = pkPoint.UnmarshalBinary(con.pk)
+
+	plaintext, status, err := decryptOne(pk, privateKeyByte, cipherBytes, id)
+	log.Debug("ArbDecryption Decrypt", "id", id, "cipherLen", len(cipherBytes), "status", status)
 	if err != nil {
-		log.Printf("Decrypt: Error unmarshalling stored public key: %v\n", err)
-		return []byte{2}, err
+		return nil, err
+	}
+	if status != 0 {
+		return nil, decryptStatusError(status)
 	}
+	return plaintext, nil
+}
 
-	// Log public and private keys
-	log.Printf("Decrypt: Public key: %v\n", pkPoint)
-	log.Printf("Decrypt: Private key: %v\n", privateKeyPoint)
+// decryptStatusError turns one of decryptOneWithQid's legacy status codes
+// into an error, so e.g. a pairing mismatch (status 4) is distinguishable
+// from any other empty-plaintext outcome instead of being silently
+// swallowed into a (nil, nil) return.
+func decryptStatusError(status uint8) error {
+	switch status {
+	case 1:
+		return errors.New("decrypt: invalid private key encoding")
+	case 2:
+		return errors.New("decrypt: invalid public key encoding")
+	case 3:
+		return errors.New("decrypt: failed to hash identity to G2")
+	case 4:
+		return errors.New("decrypt: private key does not match public key for this identity")
+	case 5:
+		return errors.New("decrypt: failed to buffer ciphertext")
+	case 6:
+		return errors.New("decrypt: symmetric decryption failed")
+	default:
+		return fmt.Errorf("decrypt: failed with status %d", status)
+	}
+}
+
+// BatchDecrypt decrypts many ciphertexts in one call, amortizing the
+// identity hash Qid = H(id) across entries that repeat an id so the G2 hash
+// is computed once per distinct id rather than once per entry. status[i]
+// mirrors Decrypt's legacy []byte{n} status codes, but as a single byte per
+// entry instead of being mixed into the returned plaintext.
+func (con *ArbDecryption) BatchDecrypt(
+	c ctx, evm mech, privateKeys [][]byte, ciphertexts [][]byte, ids []string,
+) ([][]byte, []uint8, error) {
+	if len(privateKeys) != len(ciphertexts) || len(privateKeys) != len(ids) {
+		return nil, nil, errors.New("BatchDecrypt: privateKeys, ciphertexts and ids must have equal length")
+	}
 
-	// Hash the identity to G2
+	var totalGas uint64
+	for _, cipherBytes := range ciphertexts {
+		if err := ValidateCiphertext(cipherBytes); err != nil {
+			return nil, nil, err
+		}
+		totalGas += decryptGas(len(cipherBytes))
+	}
+	if err := c.Burner().Burn(totalGas); err != nil {
+		return nil, nil, err
+	}
+
+	pk, err := c.State.Decryption().PublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	suite := bls.NewBLS12381Suite()
 	hG2, ok := suite.G2().Point().(kyber.HashablePoint)
 	if !ok {
-		log.Println("Decrypt: Hashing to G2 failed.")
-		return []byte{3}, fmt.Errorf("failed to hash to G2")
+		return nil, nil, fmt.Errorf("failed to hash to G2")
+	}
+	qidCache := make(map[string]kyber.Point, len(ids))
+	qidFor := func(id string) kyber.Point {
+		if q, ok := qidCache[id]; ok {
+			return q
+		}
+		q := hG2.Hash([]byte(id))
+		qidCache[id] = q
+		return q
 	}
 
-	idByte := []byte(id)
-	Qid := hG2.Hash(idByte)
+	plaintexts := make([][]byte, len(ids))
+	statuses := make([]uint8, len(ids))
+	for i := range ids {
+		plaintext, status, decryptErr := decryptOneWithQid(pk, privateKeys[i], ciphertexts[i], qidFor(ids[i]))
+		plaintexts[i] = plaintext
+		statuses[i] = status
+		if decryptErr != nil {
+			return plaintexts, statuses, decryptErr
+		}
+	}
 
-	// Log the hashed identity
-	log.Printf("Decrypt: Hashed identity (Qid): %v\n", Qid)
+	log.Debug("ArbDecryption BatchDecrypt", "entries", len(ids), "uniqueIds", len(qidCache))
+	return plaintexts, statuses, nil
+}
 
-	// Perform the pairing operations
-	p1 := suite.Pair(pkPoint, Qid)
-	p2 := suite.Pair(suite.G1().Point().Base(), privateKeyPoint)
+// decryptOne is Decrypt's crypto body, split out so BatchDecrypt can reuse it
+// with a precomputed Qid.
+func decryptOne(pk, privateKeyByte, cipherBytes []byte, id string) ([]byte, uint8, error) {
+	suite := bls.NewBLS12381Suite()
+	hG2, ok := suite.G2().Point().(kyber.HashablePoint)
+	if !ok {
+		return nil, 3, fmt.Errorf("failed to hash to G2")
+	}
+	return decryptOneWithQid(pk, privateKeyByte, cipherBytes, hG2.Hash([]byte(id)))
+}
 
-	// Log the pairing results
-	log.Printf("Decrypt: Pairing result p1: %v\n", p1)
-	log.Printf("Decrypt: Pairing result p2: %v\n", p2)
+func decryptOneWithQid(pk, privateKeyByte, cipherBytes []byte, Qid kyber.Point) ([]byte, uint8, error) {
+	suite := bls.NewBLS12381Suite()
 
-	// Check if the pairings are equal
-	if !p1.Equal(p2) {
-		log.Println("Decrypt: Pairing verification failed. p1 does not equal p2.")
-		return []byte{4}, nil
+	privateKeyPoint := suite.G2().Point()
+	if err := privateKeyPoint.UnmarshalBinary(privateKeyByte); err != nil {
+		return nil, 1, err
 	}
 
-	// Decrypt the ciphertext
-	var destPlainText bytes.Buffer
-	var cipherBuffer bytes.Buffer
-	_, err = cipherBuffer.Write(cipherBytes)
-	if err != nil {
-		log.Printf("Decrypt: Error writing cipher bytes to buffer: %v\n", err)
-		return []byte{5}, err
+	pkPoint := suite.G1().Point()
+	if err := pkPoint.UnmarshalBinary(pk); err != nil {
+		return nil, 2, err
 	}
 
-	log.Println("Decrypt: Starting decryption process...")
+	p1 := suite.Pair(pkPoint, Qid)
+	p2 := suite.Pair(suite.G1().Point().Base(), privateKeyPoint)
+	if !p1.Equal(p2) {
+		return nil, 4, nil
+	}
 
-	// Perform the actual decryption
-	err = enc.Decrypt(privateKeyPoint, privateKeyPoint, &destPlainText, &cipherBuffer)
-	if err != nil {
-		log.Printf("Decrypt: Error during decryption: %v\n", err)
-		return []byte{6}, err
+	var destPlainText, cipherBuffer bytes.Buffer
+	if _, err := cipherBuffer.Write(cipherBytes); err != nil {
+		return nil, 5, err
+	}
+	if err := enc.Decrypt(privateKeyPoint, privateKeyPoint, &destPlainText, &cipherBuffer); err != nil {
+		return nil, 6, err
 	}
 
-	log.Println("Decrypt: Decryption successful.")
-	return []byte(destPlainText.String()), nil
+	return destPlainText.Bytes(), 0, nil
 }
@@ -0,0 +1,47 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+// ArbPrecompileRegistry lets governance enable or disable the precompiles
+// registered in the package-level Registry (see registry.go) on a
+// per-ArbOS-version basis, journaling each activation/deactivation the same
+// way ArbOwner journals StylusParams changes.
+type ArbPrecompileRegistry struct {
+	Address addr // 0x72
+}
+
+// IsEnabled reports whether governance has enabled address as of the current
+// ArbOS version. Entries added with RegisterAlwaysOn are callable whatever
+// this reports.
+func (con ArbPrecompileRegistry) IsEnabled(c ctx, evm mech, address addr) (bool, error) {
+	arbosVersion, err := c.State.ArbOSVersion()
+	if err != nil {
+		return false, err
+	}
+	return c.State.PrecompileRegistry().IsEnabled(address, arbosVersion)
+}
+
+// Enable activates address starting at the current ArbOS version. Only
+// the entries already present in the package-level Registry can usefully be
+// enabled; enabling an address nothing is registered at is a no-op at call
+// time (Run will still fail to find a factory).
+func (con ArbPrecompileRegistry) Enable(c ctx, evm mech, address addr) (bool, error) {
+	arbosVersion, err := c.State.ArbOSVersion()
+	if err != nil {
+		return false, err
+	}
+	if err := c.State.PrecompileRegistry().Enable(address, arbosVersion); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Disable deactivates address. Calls into an entry added with Register revert
+// until it's re-enabled; entries added with RegisterAlwaysOn are unaffected.
+func (con ArbPrecompileRegistry) Disable(c ctx, evm mech, address addr) (bool, error) {
+	if err := c.State.PrecompileRegistry().Disable(address); err != nil {
+		return false, err
+	}
+	return true, nil
+}
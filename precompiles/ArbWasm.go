@@ -10,7 +10,7 @@ type ArbWasm struct {
 // Compile a wasm program with the latest instrumentation
 func (con ArbWasm) CompileProgram(c ctx, evm mech, program addr) (uint32, error) {
 	// TODO: pay for gas by some compilation pricing formula
-	return c.State.Programs().CompileProgram(evm.StateDB, program)
+	return c.State.Programs().CompileProgram(evm.StateDB, program, evm.Context.BlockNumber.Uint64())
 }
 
 // Calls a wasm program
@@ -20,7 +20,10 @@ func (con ArbWasm) CallProgram(c ctx, evm mech, program addr, calldata []byte) (
 	programs := c.State.Programs()
 
 	// give all gas to the program
-	return programs.CallProgram(evm.StateDB, program, calldata, &c.gasLeft)
+	return programs.CallProgram(
+		evm.StateDB, program, calldata, &c.gasLeft, c.readOnly,
+		evm.TxContext, evm.Context, c.txHash,
+	)
 }
 
 // Gets the latest stylus version
@@ -43,3 +46,23 @@ func (con ArbWasm) WasmMaxDepth(c ctx, evm mech) (uint32, error) {
 func (con ArbWasm) WasmHostioCost(c ctx, evm mech) (uint64, error) {
 	return c.State.Programs().WasmHostioCost()
 }
+
+// Reactivate brings a program the reaper deactivated back to life, charging
+// the EIP-1559-style congestion fee StylusParams.ReactivationFee prices off
+// the current count of active programs.
+func (con ArbWasm) Reactivate(c ctx, evm mech, program addr) (uint64, error) {
+	programs := c.State.Programs()
+
+	fee, err := programs.ReactivationFee(program)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.Burner().Burn(fee); err != nil {
+		return 0, err
+	}
+	blockNumber := evm.Context.BlockNumber.Uint64()
+	if err := programs.Reactivate(evm.StateDB, program, blockNumber); err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// This file hand-rolls just enough Solidity ABI encoding/decoding for the
+// registry's StatefulPrecompile.Run adapters to dispatch real calldata onto
+// the existing precompile methods. It only covers the argument and return
+// shapes those methods actually use (address, bytes, string, uint8/uint32/
+// uint64, bool, and dynamic arrays of bytes/string) -- it is not a general
+// ABI codec.
+
+var errAbiInput = errors.New("precompile: malformed calldata")
+
+// abiSelector splits input into its leading 4-byte selector and the
+// remaining head/tail argument words.
+func abiSelector(input []byte) ([4]byte, []byte, error) {
+	if len(input) < 4 {
+		return [4]byte{}, nil, errAbiInput
+	}
+	var sel [4]byte
+	copy(sel[:], input[:4])
+	return sel, input[4:], nil
+}
+
+func abiWord(args []byte, index int) ([32]byte, error) {
+	start := index * 32
+	if start+32 > len(args) {
+		return [32]byte{}, errAbiInput
+	}
+	var word [32]byte
+	copy(word[:], args[start:start+32])
+	return word, nil
+}
+
+func abiUint64(args []byte, index int) (uint64, error) {
+	word, err := abiWord(args, index)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(word[24:]), nil
+}
+
+func abiAddress(args []byte, index int) (common.Address, error) {
+	word, err := abiWord(args, index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(word[12:]), nil
+}
+
+// abiBytesAt decodes a dynamic bytes/string value -- a length word followed
+// by the right-padded data -- starting at byte offset within args.
+func abiBytesAt(args []byte, offset uint64) ([]byte, error) {
+	if offset+32 > uint64(len(args)) {
+		return nil, errAbiInput
+	}
+	length := binary.BigEndian.Uint64(args[offset+24 : offset+32])
+	start := offset + 32
+	// Bound length against what's actually left in args before adding it to
+	// start: args is attacker-controlled calldata, and a length near
+	// math.MaxUint64 would otherwise wrap start+length below start, passing
+	// the naive "end > len(args)" check and panicking on the slice below.
+	remaining := uint64(len(args)) - start
+	if length > remaining {
+		return nil, errAbiInput
+	}
+	return args[start : start+length], nil
+}
+
+// abiBytes decodes the dynamic bytes/string argument at index, whose head
+// word holds an offset to the length-prefixed value.
+func abiBytes(args []byte, index int) ([]byte, error) {
+	offsetWord, err := abiWord(args, index)
+	if err != nil {
+		return nil, err
+	}
+	return abiBytesAt(args, binary.BigEndian.Uint64(offsetWord[24:]))
+}
+
+func abiString(args []byte, index int) (string, error) {
+	data, err := abiBytes(args, index)
+	return string(data), err
+}
+
+// abiBytesArray decodes a dynamic array of dynamic bytes/string values: the
+// head word at index offsets to the array's data, which starts with the
+// element count, then one offset word per element (relative to the start of
+// that data), then each element's own length+data.
+func abiBytesArray(args []byte, index int) ([][]byte, error) {
+	offsetWord, err := abiWord(args, index)
+	if err != nil {
+		return nil, err
+	}
+	arrayOffset := binary.BigEndian.Uint64(offsetWord[24:])
+	if arrayOffset+32 > uint64(len(args)) {
+		return nil, errAbiInput
+	}
+	count := binary.BigEndian.Uint64(args[arrayOffset+24 : arrayOffset+32])
+	elementsStart := arrayOffset + 32
+	if elementsStart > uint64(len(args)) {
+		return nil, errAbiInput
+	}
+	// Every element needs at least its own 32-byte offset word, so a count
+	// that can't possibly fit in what's left of args is malformed -- bound it
+	// before make([][]byte, count) rather than trusting an attacker-supplied
+	// count straight out of calldata (it would otherwise panic with
+	// "makeslice: len out of range" or drive a huge allocation).
+	maxCount := (uint64(len(args)) - elementsStart) / 32
+	if count > maxCount {
+		return nil, errAbiInput
+	}
+	out := make([][]byte, count)
+	for i := uint64(0); i < count; i++ {
+		wordStart := elementsStart + i*32
+		if wordStart+32 > uint64(len(args)) {
+			return nil, errAbiInput
+		}
+		elementOffset := binary.BigEndian.Uint64(args[wordStart+24 : wordStart+32])
+		data, err := abiBytesAt(args, elementsStart+elementOffset)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+func abiStringArray(args []byte, index int) ([]string, error) {
+	raw, err := abiBytesArray(args, index)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(raw))
+	for i, b := range raw {
+		out[i] = string(b)
+	}
+	return out, nil
+}
+
+// abiEncodeUint64 encodes a static uint-family return value as a single
+// 32-byte word.
+func abiEncodeUint64(x uint64) []byte {
+	word := make([]byte, 32)
+	binary.BigEndian.PutUint64(word[24:], x)
+	return word
+}
+
+func abiEncodeBool(b bool) []byte {
+	word := make([]byte, 32)
+	if b {
+		word[31] = 1
+	}
+	return word
+}
+
+// abiEncodeBytesValue encodes a single bytes/string value's length+data,
+// without the leading offset word a caller placing it in a tuple supplies.
+func abiEncodeBytesValue(data []byte) []byte {
+	padded := (len(data) + 31) / 32 * 32
+	out := make([]byte, 32+padded)
+	binary.BigEndian.PutUint64(out[24:32], uint64(len(data)))
+	copy(out[32:32+len(data)], data)
+	return out
+}
+
+// abiEncodeBytesArrayValue encodes a bytes[]/string[] value: count, one
+// offset word per element, then each element's length+data.
+func abiEncodeBytesArrayValue(elements [][]byte) []byte {
+	head := make([]byte, 32*len(elements))
+	var tail []byte
+	for i, data := range elements {
+		binary.BigEndian.PutUint64(head[i*32+24:i*32+32], uint64(len(head)+len(tail)))
+		tail = append(tail, abiEncodeBytesValue(data)...)
+	}
+	out := append(abiEncodeUint64(uint64(len(elements))), head...)
+	return append(out, tail...)
+}
+
+// abiEncodeUint8ArrayValue encodes a uint8[] value: count, then one
+// right-padded word per element (uint8 is static, so no inner offsets).
+func abiEncodeUint8ArrayValue(values []uint8) []byte {
+	out := make([]byte, 32+32*len(values))
+	copy(out[:32], abiEncodeUint64(uint64(len(values))))
+	for i, v := range values {
+		out[32+i*32+31] = v
+	}
+	return out
+}
+
+// abiEncodeTuple lays out a return tuple of dynamic values: one offset word
+// per value, followed by each value's own encoding, in order.
+func abiEncodeTuple(values ...[]byte) []byte {
+	head := make([]byte, 32*len(values))
+	var tail []byte
+	for i, value := range values {
+		binary.BigEndian.PutUint64(head[i*32+24:i*32+32], uint64(len(head)+len(tail)))
+		tail = append(tail, value...)
+	}
+	return append(head, tail...)
+}
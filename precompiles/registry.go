@@ -0,0 +1,161 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StatefulPrecompile is the interface a precompile must satisfy to be served
+// out of the Registry, rather than being hard-coded into the precompile
+// dispatcher. It mirrors the shape of the existing concrete precompiles
+// (ArbWasm, ArbDecryption, ...) so that they can be adapted without changing
+// their method signatures.
+type StatefulPrecompile interface {
+	// Selectors returns the 4-byte method selectors this precompile answers to,
+	// alongside the gas each one costs to dispatch (the method body may burn more).
+	Selectors() map[[4]byte]uint64
+
+	// Run executes the call, dispatching on the leading 4-byte selector in input.
+	Run(c ctx, evm mech, input []byte, readOnly bool) ([]byte, error)
+}
+
+// PrecompileFactory builds a fresh StatefulPrecompile bound to the context and
+// EVM of a particular call. Precompiles that are pure structs (ArbWasm) can
+// ignore the arguments; stateful ones may use them to reach backing storage.
+type PrecompileFactory func(c ctx, evm mech) StatefulPrecompile
+
+// Registry is a pluggable map from precompile address to the factory that
+// builds it, replacing the previous pattern of enumerating every precompile
+// as a hard-coded Go type. Downstream forks can add entries of their own via
+// Register without touching this package.
+//
+// Entries registered via Register are gated by ArbPrecompileRegistry /
+// arbos/precompileregistry: Lookup refuses to build them unless governance
+// has enabled them for the current ArbOS version. RegisterAlwaysOn opts an
+// entry out of that gate, for the core precompiles this tree ships with,
+// which must keep working whether or not governance has ever touched
+// ArbPrecompileRegistry.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[addr]PrecompileFactory
+	alwaysOn  map[addr]bool
+}
+
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty Registry. Most callers want the package-level
+// default registry reached via Register/Lookup/Registered.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[addr]PrecompileFactory),
+		alwaysOn:  make(map[addr]bool),
+	}
+}
+
+// Register associates a precompile address with the factory that builds it,
+// subject to the ArbPrecompileRegistry enable/disable gate. Re-registering an
+// address replaces the previous factory, which lets forks override a stock
+// precompile as well as add new ones.
+func (r *Registry) Register(address addr, factory PrecompileFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[address] = factory
+	delete(r.alwaysOn, address)
+}
+
+// RegisterAlwaysOn is Register for a precompile that bypasses the
+// ArbPrecompileRegistry gate entirely -- meant for the precompiles this tree
+// ships with (ArbWasm, ArbDecryption), not entries a fork adds later that
+// governance should be able to toggle.
+func (r *Registry) RegisterAlwaysOn(address addr, factory PrecompileFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[address] = factory
+	r.alwaysOn[address] = true
+}
+
+// Lookup builds the precompile registered at address, if any, and if
+// governance hasn't disabled it (RegisterAlwaysOn entries skip that check).
+func (r *Registry) Lookup(c ctx, evm mech, address addr) (StatefulPrecompile, bool) {
+	r.mu.RLock()
+	factory, ok := r.factories[address]
+	alwaysOn := r.alwaysOn[address]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if !alwaysOn {
+		enabled, err := r.isEnabled(c, address)
+		if err != nil || !enabled {
+			return nil, false
+		}
+	}
+	return factory(c, evm), true
+}
+
+// isEnabled consults ArbPrecompileRegistry's activation state for address at
+// the current ArbOS version, the same check ArbPrecompileRegistry.IsEnabled
+// exposes to callers.
+func (r *Registry) isEnabled(c ctx, address addr) (bool, error) {
+	arbosVersion, err := c.State.ArbOSVersion()
+	if err != nil {
+		return false, err
+	}
+	return c.State.PrecompileRegistry().IsEnabled(address, arbosVersion)
+}
+
+// Registered reports the set of addresses currently served by the registry.
+func (r *Registry) Registered() []addr {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	addrs := make([]addr, 0, len(r.factories))
+	for address := range r.factories {
+		addrs = append(addrs, address)
+	}
+	return addrs
+}
+
+// Register adds a precompile to the default registry, gated by
+// ArbPrecompileRegistry. This is the extension hook downstream forks use to
+// install additional precompiles (more Fairblock IBE variants, aggregation,
+// KZG, ...) without patching this package.
+func Register(address addr, factory PrecompileFactory) {
+	defaultRegistry.Register(address, factory)
+}
+
+// RegisterAlwaysOn adds a precompile to the default registry that bypasses
+// the ArbPrecompileRegistry gate. See Registry.RegisterAlwaysOn.
+func RegisterAlwaysOn(address addr, factory PrecompileFactory) {
+	defaultRegistry.RegisterAlwaysOn(address, factory)
+}
+
+// Lookup builds the precompile registered at address in the default registry.
+func Lookup(c ctx, evm mech, address addr) (StatefulPrecompile, bool) {
+	return defaultRegistry.Lookup(c, evm, address)
+}
+
+// Run dispatches input to whatever precompile is registered at address,
+// returning an error if nothing is registered there. Before dispatching, it
+// burns the gas Selectors() declares for the leading 4-byte selector in
+// input, so that schedule isn't just documentation -- a selector absent from
+// the map (including a too-short input) burns nothing here and is left to
+// the precompile's own Run to reject.
+func Run(c ctx, evm mech, address addr, input []byte, readOnly bool) ([]byte, error) {
+	precompile, ok := Lookup(c, evm, address)
+	if !ok {
+		return nil, fmt.Errorf("no precompile registered at %v", address)
+	}
+	if len(input) >= 4 {
+		var sel [4]byte
+		copy(sel[:], input[:4])
+		if cost, ok := precompile.Selectors()[sel]; ok {
+			if err := c.Burner().Burn(cost); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return precompile.Run(c, evm, input, readOnly)
+}
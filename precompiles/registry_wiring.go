@@ -0,0 +1,230 @@
+// Copyright 2022-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// selector computes the 4-byte method selector for a Solidity-style function
+// signature, e.g. selector("compileProgram(address)").
+func selector(signature string) [4]byte {
+	var sel [4]byte
+	copy(sel[:], crypto.Keccak256([]byte(signature))[:4])
+	return sel
+}
+
+// dispatchGas is what Registry.Run burns per call just for reaching a
+// method's body, the intrinsic cost of the storage read every one of these
+// methods performs -- separate from (and in addition to) whatever gas the
+// method body itself burns for the work it does (e.g. ArbDecryption.Decrypt's
+// pairing cost).
+const dispatchGas = params.WarmStorageReadCostEIP2929
+
+var (
+	selCompileProgram = selector("compileProgram(address)")
+	selCallProgram    = selector("callProgram(address,bytes)")
+	selStylusVersion  = selector("stylusVersion()")
+	selWasmGasPrice   = selector("wasmGasPrice()")
+	selWasmMaxDepth   = selector("wasmMaxDepth()")
+	selWasmHostioCost = selector("wasmHostioCost()")
+	selReactivate     = selector("reactivate(address)")
+
+	selGet          = selector("get()")
+	selSet          = selector("set(bytes)")
+	selDecrypt      = selector("decrypt(bytes,bytes,string)")
+	selBatchDecrypt = selector("batchDecrypt(bytes[],bytes[],string[])")
+)
+
+var errUnknownSelector = errors.New("precompile: unknown selector")
+
+// arbWasmPrecompile adapts the existing ArbWasm struct to StatefulPrecompile
+// so it can be served out of the Registry instead of being hard-coded into
+// the precompile dispatcher.
+type arbWasmPrecompile struct {
+	con ArbWasm
+}
+
+func (p *arbWasmPrecompile) Selectors() map[[4]byte]uint64 {
+	return map[[4]byte]uint64{
+		selCompileProgram: dispatchGas,
+		selCallProgram:    dispatchGas,
+		selStylusVersion:  dispatchGas,
+		selWasmGasPrice:   dispatchGas,
+		selWasmMaxDepth:   dispatchGas,
+		selWasmHostioCost: dispatchGas,
+		selReactivate:     dispatchGas,
+	}
+}
+
+// Run dispatches calldata onto the matching ArbWasm method by hand-decoding
+// the handful of argument/return shapes these methods use (address, bytes,
+// uint). It doesn't need the solgen ABI encoder the rest of the precompiles
+// package is generated with; abi_codec.go carries just enough of that by
+// hand.
+func (p *arbWasmPrecompile) Run(c ctx, evm mech, input []byte, readOnly bool) ([]byte, error) {
+	sel, args, err := abiSelector(input)
+	if err != nil {
+		return nil, err
+	}
+	switch sel {
+	case selCompileProgram:
+		program, err := abiAddress(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		version, err := p.con.CompileProgram(c, evm, program)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(uint64(version)), nil
+	case selCallProgram:
+		program, err := abiAddress(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		calldata, err := abiBytes(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		output, err := p.con.CallProgram(c, evm, program, calldata)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeTuple(abiEncodeBytesValue(output)), nil
+	case selStylusVersion:
+		version, err := p.con.StylusVersion(c, evm)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(uint64(version)), nil
+	case selWasmGasPrice:
+		price, err := p.con.WasmGasPrice(c, evm)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(price), nil
+	case selWasmMaxDepth:
+		depth, err := p.con.WasmMaxDepth(c, evm)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(uint64(depth)), nil
+	case selWasmHostioCost:
+		cost, err := p.con.WasmHostioCost(c, evm)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(cost), nil
+	case selReactivate:
+		program, err := abiAddress(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		fee, err := p.con.Reactivate(c, evm, program)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeUint64(fee), nil
+	default:
+		return nil, fmt.Errorf("%w: %x", errUnknownSelector, sel)
+	}
+}
+
+// arbDecryptionPrecompile adapts the existing ArbDecryption struct to
+// StatefulPrecompile.
+type arbDecryptionPrecompile struct {
+	con *ArbDecryption
+}
+
+func (p *arbDecryptionPrecompile) Selectors() map[[4]byte]uint64 {
+	return map[[4]byte]uint64{
+		selGet:          dispatchGas,
+		selSet:          dispatchGas,
+		selDecrypt:      dispatchGas,
+		selBatchDecrypt: dispatchGas,
+	}
+}
+
+func (p *arbDecryptionPrecompile) Run(c ctx, evm mech, input []byte, readOnly bool) ([]byte, error) {
+	sel, args, err := abiSelector(input)
+	if err != nil {
+		return nil, err
+	}
+	switch sel {
+	case selGet:
+		pk, err := p.con.Get(c, evm)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeTuple(abiEncodeBytesValue(pk)), nil
+	case selSet:
+		pk, err := abiBytes(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := p.con.Set(c, evm, pk)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeBool(ok), nil
+	case selDecrypt:
+		privateKey, err := abiBytes(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		cipherBytes, err := abiBytes(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		id, err := abiString(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := p.con.Decrypt(c, evm, privateKey, cipherBytes, id)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeTuple(abiEncodeBytesValue(plaintext)), nil
+	case selBatchDecrypt:
+		privateKeys, err := abiBytesArray(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		ciphertexts, err := abiBytesArray(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		ids, err := abiStringArray(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		plaintexts, statuses, err := p.con.BatchDecrypt(c, evm, privateKeys, ciphertexts, ids)
+		if err != nil {
+			return nil, err
+		}
+		return abiEncodeTuple(abiEncodeBytesArrayValue(plaintexts), abiEncodeUint8ArrayValue(statuses)), nil
+	default:
+		return nil, fmt.Errorf("%w: %x", errUnknownSelector, sel)
+	}
+}
+
+var arbWasmAddress = common.HexToAddress("0x71")
+var arbDecryptionAddress = common.HexToAddress("0x23")
+
+func init() {
+	// Core precompiles this tree ships with: always on, not subject to the
+	// ArbPrecompileRegistry gate.
+	RegisterAlwaysOn(arbWasmAddress, func(c ctx, evm mech) StatefulPrecompile {
+		return &arbWasmPrecompile{con: ArbWasm{Address: arbWasmAddress}}
+	})
+	RegisterAlwaysOn(arbDecryptionAddress, func(c ctx, evm mech) StatefulPrecompile {
+		return &arbDecryptionPrecompile{con: &ArbDecryption{Address: arbDecryptionAddress}}
+	})
+}